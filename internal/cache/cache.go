@@ -0,0 +1,176 @@
+// Package cache provides a fingerprint-keyed cache for computed devices so
+// that build cycles can skip precompute/compute for inputs that did not
+// change since the last run.
+package cache
+
+import (
+	"container/list"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+)
+
+// entry is a single cached device keyed by the fingerprint of the inputs
+// that produced it. Fields are exported so the struct can be gob-encoded
+// for on-disk persistence.
+type entry struct {
+	Hostname    string
+	Fingerprint string
+	Dev         *device.Device
+}
+
+// Cache is an in-memory LRU cache of *device.Device keyed by the fingerprint
+// of the CMDB assets that produced them, with optional on-disk persistence
+// so that the cache survives process restarts.
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	dir      string
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+// New creates a new Cache holding up to capacity entries.
+// If dir is non-empty, entries are additionally persisted to that directory
+// and reloaded on the next call to Load.
+func New(capacity int, dir string) *Cache {
+	return &Cache{
+		capacity: capacity,
+		dir:      dir,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached device for hostname if its current fingerprint
+// matches the one it was cached with.
+func (c *Cache) Get(hostname, fingerprint string) (*device.Device, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[hostname]
+	if !ok {
+		return nil, false
+	}
+
+	e := elem.Value.(*entry)
+	if e.Fingerprint != fingerprint {
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return e.Dev, true
+}
+
+// Set stores dev in the cache under hostname, keyed by fingerprint,
+// evicting the least recently used entry if the cache is full.
+func (c *Cache) Set(hostname, fingerprint string, dev *device.Device) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[hostname]; ok {
+		elem.Value.(*entry).Fingerprint = fingerprint
+		elem.Value.(*entry).Dev = dev
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	elem := c.order.PushFront(&entry{Hostname: hostname, Fingerprint: fingerprint, Dev: dev})
+	c.items[hostname] = elem
+
+	if c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		if oldest != nil {
+			c.order.Remove(oldest)
+			delete(c.items, oldest.Value.(*entry).Hostname)
+		}
+	}
+}
+
+// Save persists the cache to disk as one gob-encoded file per entry.
+// It is a no-op when the cache was created without a directory.
+func (c *Cache) Save() error {
+	if c.dir == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*entry)
+		if err := c.saveEntry(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Cache) saveEntry(e *entry) error {
+	f, err := os.Create(filepath.Join(c.dir, e.Hostname+".gob"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return gob.NewEncoder(f).Encode(e)
+}
+
+// Load repopulates the cache from the on-disk persistence directory.
+// It is a no-op when the cache was created without a directory, and
+// ignores entries it cannot decode.
+func (c *Cache) Load() {
+	if c.dir == "" {
+		return
+	}
+
+	matches, err := filepath.Glob(filepath.Join(c.dir, "*.gob"))
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to list cache directory")
+		return
+	}
+
+	for _, path := range matches {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+
+		var e entry
+		err = gob.NewDecoder(f).Decode(&e)
+		f.Close()
+		if err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("failed to decode cache entry, skipping")
+			continue
+		}
+
+		elem := c.order.PushFront(&e)
+		c.items[e.Hostname] = elem
+	}
+
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry).Hostname)
+	}
+
+	log.Info().Int("count", len(c.items)).Str("dir", c.dir).Msg("loaded build cache from disk")
+}
+
+// Len returns the number of entries currently held in the cache.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
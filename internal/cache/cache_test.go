@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+)
+
+func TestCacheGetSetRoundTrip(t *testing.T) {
+	c := New(0, "")
+
+	if _, ok := c.Get("device-a", "fp1"); ok {
+		t.Fatal("expected miss on empty cache")
+	}
+
+	c.Set("device-a", "fp1", nil)
+
+	if _, ok := c.Get("device-a", "fp1"); !ok {
+		t.Fatal("expected hit for matching fingerprint")
+	}
+
+	if _, ok := c.Get("device-a", "fp2"); ok {
+		t.Fatal("expected miss for changed fingerprint")
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := New(2, "")
+
+	c.Set("device-a", "fp", nil)
+	c.Set("device-b", "fp", nil)
+
+	// Touch device-a so device-b becomes the least recently used entry.
+	if _, ok := c.Get("device-a", "fp"); !ok {
+		t.Fatal("expected hit for device-a")
+	}
+
+	c.Set("device-c", "fp", nil)
+
+	if c.Len() != 2 {
+		t.Fatalf("expected cache to stay at capacity 2, got %d", c.Len())
+	}
+
+	if _, ok := c.Get("device-b", "fp"); ok {
+		t.Fatal("expected device-b to have been evicted as least recently used")
+	}
+
+	if _, ok := c.Get("device-a", "fp"); !ok {
+		t.Fatal("expected device-a to still be cached")
+	}
+
+	if _, ok := c.Get("device-c", "fp"); !ok {
+		t.Fatal("expected device-c to be cached")
+	}
+}
+
+func TestCacheSaveLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	// Use populated, non-nil devices: a nil *device.Device would gob-encode
+	// trivially even if device.Device itself had an unencodable field, and
+	// would not have caught the original "entry has no exported fields" bug.
+	devA := &device.Device{Hostname: "device-a"}
+	devB := &device.Device{Hostname: "device-b"}
+
+	c := New(0, dir)
+	c.Set("device-a", "fp1", devA)
+	c.Set("device-b", "fp2", devB)
+
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+
+	reloaded := New(0, dir)
+	reloaded.Load()
+
+	if reloaded.Len() != 2 {
+		t.Fatalf("expected 2 entries after Load, got %d", reloaded.Len())
+	}
+
+	got, ok := reloaded.Get("device-a", "fp1")
+	if !ok {
+		t.Fatal("expected device-a to be restored from disk with its fingerprint")
+	}
+	if got == nil || got.Hostname != "device-a" {
+		t.Fatalf("expected the decoded device to keep its data, got %+v", got)
+	}
+
+	if _, ok := reloaded.Get("device-b", "fp2"); !ok {
+		t.Fatal("expected device-b to be restored from disk with its fingerprint")
+	}
+}
+
+func TestCacheSaveNoopWithoutDir(t *testing.T) {
+	c := New(0, "")
+	c.Set("device-a", "fp1", nil)
+
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save should be a no-op without a persistence dir, got: %v", err)
+	}
+}
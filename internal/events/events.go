@@ -0,0 +1,117 @@
+// Package events provides a typed, pluggable alternative to the
+// in-memory-only report stream: each build emits structured events that are
+// fanned out to a configurable set of sinks, so that operators can keep
+// build history beyond the last report snapshot exposed by the API.
+package events
+
+import "time"
+
+// Type identifies the kind of build event.
+type Type string
+
+const (
+	// BuildStarted is emitted once at the beginning of a build cycle.
+	BuildStarted Type = "build_started"
+	// FetchDone is emitted once CMDB data has been fetched from every ingestor.
+	FetchDone Type = "fetch_done"
+	// DeviceBuilt is emitted once per device that was successfully built.
+	DeviceBuilt Type = "device_built"
+	// DeviceFailed is emitted once per device whose build failed.
+	DeviceFailed Type = "device_failed"
+	// BuildComplete is emitted once at the end of a build cycle that reaches
+	// compute, whether compute succeeded or not. A cycle that stops earlier
+	// (failed fetch, or a precompute failure with AllDevicesMustBuild) emits
+	// no BuildComplete.
+	BuildComplete Type = "build_complete"
+)
+
+// Event is a single structured build event.
+//
+// Only the fields relevant to Type are populated; the others are left at
+// their zero value.
+type Event struct {
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+
+	// Hostname identifies the device a DeviceBuilt/DeviceFailed event is about.
+	Hostname string `json:"hostname,omitempty"`
+	// Duration is how long building Hostname took.
+	Duration time.Duration `json:"duration_ns,omitempty"`
+	// Bytes is the size of the OpenConfig generated for Hostname.
+	Bytes int `json:"bytes,omitempty"`
+	// Hash is the fingerprint of the inputs that produced Hostname's OpenConfig.
+	Hash string `json:"hash,omitempty"`
+	// Error is the failure reason for a DeviceFailed event.
+	Error string `json:"error,omitempty"`
+
+	// Stats carries the final build statistics for a BuildComplete event.
+	Stats BuildStats `json:"stats,omitempty"`
+}
+
+// BuildStats is the subset of report.Stats worth keeping in long-term
+// event history.
+type BuildStats struct {
+	BuiltDevicesCount  uint32        `json:"built_devices_count,omitempty"`
+	FailedDevicesCount uint32        `json:"failed_devices_count,omitempty"`
+	BuildDuration      time.Duration `json:"build_duration_ns,omitempty"`
+}
+
+// Sink receives build events. Implementations must be safe for concurrent
+// use, since events from different devices are emitted concurrently.
+type Sink interface {
+	Emit(Event)
+	// Close flushes and releases any resource held by the sink.
+	Close() error
+}
+
+// FanOut is a Sink that forwards every event to a fixed set of sinks.
+type FanOut struct {
+	sinks []Sink
+}
+
+// NewFanOut returns a Sink that forwards every event to each of sinks.
+func NewFanOut(sinks ...Sink) *FanOut {
+	return &FanOut{sinks: sinks}
+}
+
+// Emit forwards event to every underlying sink.
+func (f *FanOut) Emit(event Event) {
+	for _, sink := range f.sinks {
+		sink.Emit(event)
+	}
+}
+
+// Close closes every underlying sink, returning the first error encountered.
+func (f *FanOut) Close() error {
+	var firstErr error
+	for _, sink := range f.sinks {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Flusher is implemented by sinks that buffer state across a build cycle
+// (such as the Prometheus vectors a PushgatewaySink accumulates) and need
+// an explicit per-cycle flush point, as opposed to Close which only runs
+// once at process shutdown.
+type Flusher interface {
+	Flush() error
+}
+
+// Flush flushes every underlying sink that implements Flusher, returning
+// the first error encountered.
+func (f *FanOut) Flush() error {
+	var firstErr error
+	for _, sink := range f.sinks {
+		flusher, ok := sink.(Flusher)
+		if !ok {
+			continue
+		}
+		if err := flusher.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
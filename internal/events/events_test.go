@@ -0,0 +1,85 @@
+package events
+
+import (
+	"errors"
+	"testing"
+)
+
+type fakeSink struct {
+	emitted  []Event
+	closed   bool
+	closeErr error
+}
+
+func (f *fakeSink) Emit(event Event) { f.emitted = append(f.emitted, event) }
+func (f *fakeSink) Close() error     { f.closed = true; return f.closeErr }
+
+type fakeFlushingSink struct {
+	fakeSink
+	flushed  bool
+	flushErr error
+}
+
+func (f *fakeFlushingSink) Flush() error { f.flushed = true; return f.flushErr }
+
+func TestFanOut_EmitForwardsToEverySink(t *testing.T) {
+	a := &fakeSink{}
+	b := &fakeSink{}
+	fanOut := NewFanOut(a, b)
+
+	event := Event{Type: BuildStarted}
+	fanOut.Emit(event)
+
+	if len(a.emitted) != 1 || a.emitted[0] != event {
+		t.Fatalf("expected sink a to receive the event, got %v", a.emitted)
+	}
+	if len(b.emitted) != 1 || b.emitted[0] != event {
+		t.Fatalf("expected sink b to receive the event, got %v", b.emitted)
+	}
+}
+
+func TestFanOut_CloseClosesEveryoneAndReturnsFirstError(t *testing.T) {
+	failing := errors.New("boom")
+	a := &fakeSink{closeErr: failing}
+	b := &fakeSink{}
+	fanOut := NewFanOut(a, b)
+
+	err := fanOut.Close()
+
+	if !errors.Is(err, failing) {
+		t.Fatalf("expected the first error to be returned, got %v", err)
+	}
+	if !a.closed || !b.closed {
+		t.Fatal("expected every sink to be closed even after an earlier error")
+	}
+}
+
+func TestFanOut_FlushOnlyCallsSinksImplementingFlusher(t *testing.T) {
+	plain := &fakeSink{}
+	flushing := &fakeFlushingSink{}
+	fanOut := NewFanOut(plain, flushing)
+
+	if err := fanOut.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !flushing.flushed {
+		t.Fatal("expected the Flusher-implementing sink to be flushed")
+	}
+}
+
+func TestFanOut_FlushReturnsFirstFlusherError(t *testing.T) {
+	failing := errors.New("push failed")
+	a := &fakeFlushingSink{flushErr: failing}
+	b := &fakeFlushingSink{}
+	fanOut := NewFanOut(a, b)
+
+	err := fanOut.Flush()
+
+	if !errors.Is(err, failing) {
+		t.Fatalf("expected the first flush error to be returned, got %v", err)
+	}
+	if !b.flushed {
+		t.Fatal("expected every Flusher to still be flushed even after an earlier error")
+	}
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// FileSink appends every event as a JSON line to a file, rotating it once
+// it grows past maxBytes.
+type FileSink struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	file     *os.File
+	size     int64
+}
+
+// NewFileSink opens (creating if needed) path for append and returns a sink
+// that rotates it once it exceeds maxBytes. A maxBytes of 0 disables rotation.
+func NewFileSink(path string, maxBytes int64) (*FileSink, error) {
+	sink := &FileSink{path: path, maxBytes: maxBytes}
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open event log %s: %w", s.path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat event log %s: %w", s.path, err)
+	}
+
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// rotate renames the current log to a fixed ".1" suffix and reopens a fresh
+// file at path. Only one generation of history is ever retained: rotating
+// a second time within the same process lifetime overwrites the previous
+// ".1" file.
+func (s *FileSink) rotate() {
+	rotatedPath := s.path + "." + fmt.Sprint(1)
+	if err := s.file.Close(); err != nil {
+		log.Warn().Err(err).Msg("failed to close event log before rotation")
+	}
+	if err := os.Rename(s.path, rotatedPath); err != nil {
+		log.Warn().Err(err).Str("path", s.path).Msg("failed to rotate event log")
+	}
+	if err := s.open(); err != nil {
+		log.Warn().Err(err).Msg("failed to reopen event log after rotation")
+	}
+}
+
+// Emit appends event as a single JSON line, rotating the file first if it
+// has grown past maxBytes.
+func (s *FileSink) Emit(event Event) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to marshal build event")
+		return
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxBytes > 0 && s.size > s.maxBytes {
+		s.rotate()
+	}
+
+	n, err := s.file.Write(line)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to write build event to file sink")
+		return
+	}
+	s.size += int64(n)
+}
+
+// Close closes the underlying file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
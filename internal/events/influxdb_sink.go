@@ -0,0 +1,173 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// influxWriteQueueSize bounds how many pending line-protocol points Emit
+// will buffer before dropping events, so a slow/unreachable InfluxDB
+// endpoint cannot apply backpressure to compute().
+const influxWriteQueueSize = 4096
+
+// influxBatchInterval is how often buffered points are flushed to InfluxDB
+// when the queue isn't already full enough to trigger an eager flush.
+const influxBatchInterval = 2 * time.Second
+
+// influxBatchSize is the number of buffered points that triggers an eager
+// flush instead of waiting for influxBatchInterval.
+const influxBatchSize = 256
+
+// influxWriteTimeout bounds a single HTTP write to InfluxDB.
+const influxWriteTimeout = 5 * time.Second
+
+// InfluxDBSink writes build events as InfluxDB line protocol points to a
+// writable InfluxDB HTTP endpoint, so per-device build durations and
+// success/failure counts can be explored over time in Grafana.
+//
+// Emit only enqueues points; a single background goroutine batches and
+// writes them, so a slow or unreachable InfluxDB endpoint cannot serialize
+// or stall the compute() worker pool calling Emit.
+type InfluxDBSink struct {
+	writeURL string
+	client   *http.Client
+
+	lines chan string
+	stop  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// NewInfluxDBSink returns a sink writing to the InfluxDB `/write` endpoint
+// at writeURL (expected to already include the target bucket/database and
+// any required auth query parameters).
+func NewInfluxDBSink(writeURL string) *InfluxDBSink {
+	s := &InfluxDBSink{
+		writeURL: writeURL,
+		client:   &http.Client{Timeout: influxWriteTimeout},
+		lines:    make(chan string, influxWriteQueueSize),
+		stop:     make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.run()
+
+	return s
+}
+
+// Emit converts event to a line-protocol point and enqueues it for the
+// background writer. DeviceBuilt/DeviceFailed events become `device_build`
+// points; every other event type is dropped, since only per-device timings
+// are meaningful as a time series. If the write queue is full the event is
+// dropped and logged, rather than blocking the caller.
+func (s *InfluxDBSink) Emit(event Event) {
+	var line string
+
+	switch event.Type {
+	case DeviceBuilt:
+		line = fmt.Sprintf("device_build,hostname=%s,result=success duration_seconds=%f,bytes=%di %d\n",
+			escapeTag(event.Hostname), event.Duration.Seconds(), event.Bytes, event.Time.UnixNano())
+	case DeviceFailed:
+		line = fmt.Sprintf("device_build,hostname=%s,result=failure duration_seconds=%f %d\n",
+			escapeTag(event.Hostname), event.Duration.Seconds(), event.Time.UnixNano())
+	default:
+		return
+	}
+
+	select {
+	case s.lines <- line:
+	default:
+		log.Warn().Msg("influxdb sink write queue is full, dropping build event")
+	}
+}
+
+// run batches and writes queued points until stop is closed, then drains
+// whatever is left in the queue before returning.
+func (s *InfluxDBSink) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(influxBatchInterval)
+	defer ticker.Stop()
+
+	var batch []string
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.write(strings.Join(batch, "")); err != nil {
+			log.Warn().Err(err).Msg("failed to write build events to influxdb sink")
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, line)
+			if len(batch) >= influxBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-s.stop:
+			for {
+				select {
+				case line := <-s.lines:
+					batch = append(batch, line)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *InfluxDBSink) write(body string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), influxWriteTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.writeURL, bytes.NewBufferString(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("influxdb write returned status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// Close stops the background writer, flushing any points still queued,
+// and waits for it to finish.
+func (s *InfluxDBSink) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	s.wg.Wait()
+	return nil
+}
+
+// escapeTag escapes the characters InfluxDB line protocol requires escaped
+// in tag keys and values.
+func escapeTag(value string) string {
+	replacer := strings.NewReplacer(",", "\\,", "=", "\\=", " ", "\\ ")
+	return replacer.Replace(value)
+}
@@ -0,0 +1,94 @@
+package events
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInfluxDBSink_EmitWritesLineProtocolOnClose(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read request body: %v", err)
+			return
+		}
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	sink := NewInfluxDBSink(server.URL + "/write")
+
+	sink.Emit(Event{Type: DeviceBuilt, Hostname: "switch-1", Duration: 2 * time.Second, Bytes: 42})
+	sink.Emit(Event{Type: DeviceFailed, Hostname: "switch-2", Duration: time.Second})
+	// BuildStarted/BuildComplete carry no per-device timing and must be dropped.
+	sink.Emit(Event{Type: BuildStarted})
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close returned an error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(bodies) == 0 {
+		t.Fatal("expected Close to flush at least one write to the server")
+	}
+	combined := strings.Join(bodies, "")
+
+	if !strings.Contains(combined, "hostname=switch-1") || !strings.Contains(combined, "bytes=42i") {
+		t.Fatalf("expected a device_build point for switch-1 with its byte size, got %q", combined)
+	}
+	if !strings.Contains(combined, "hostname=switch-2") || !strings.Contains(combined, "result=failure") {
+		t.Fatalf("expected a device_build point for switch-2's failure, got %q", combined)
+	}
+	if strings.Contains(combined, "build_started") {
+		t.Fatalf("expected build_started to be dropped, got %q", combined)
+	}
+}
+
+func TestInfluxDBSink_EmitEscapesTagValues(t *testing.T) {
+	var mu sync.Mutex
+	var body string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		body += string(b)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	sink := NewInfluxDBSink(server.URL + "/write")
+	sink.Emit(Event{Type: DeviceBuilt, Hostname: "switch,with=space 1"})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close returned an error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !strings.Contains(body, `switch\,with\=space\ 1`) {
+		t.Fatalf("expected the hostname tag to be escaped, got %q", body)
+	}
+}
+
+func TestInfluxDBSink_CloseIsSafeWithNoEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("did not expect a write with no events emitted")
+	}))
+	defer server.Close()
+
+	sink := NewInfluxDBSink(server.URL + "/write")
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close returned an error: %v", err)
+	}
+}
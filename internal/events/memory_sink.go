@@ -0,0 +1,44 @@
+package events
+
+import "sync"
+
+// MemorySink keeps the last N events in memory, matching the behavior of
+// the original report.Repository last-build snapshot.
+type MemorySink struct {
+	mu     sync.Mutex
+	events []Event
+	limit  int
+}
+
+// NewMemorySink returns a MemorySink retaining at most limit events.
+// A limit of 0 means unbounded.
+func NewMemorySink(limit int) *MemorySink {
+	return &MemorySink{limit: limit}
+}
+
+// Emit appends event to the in-memory history, dropping the oldest event
+// once limit is reached.
+func (s *MemorySink) Emit(event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, event)
+	if s.limit > 0 && len(s.events) > s.limit {
+		s.events = s.events[len(s.events)-s.limit:]
+	}
+}
+
+// Events returns a copy of the events currently held in memory.
+func (s *MemorySink) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Event, len(s.events))
+	copy(out, s.events)
+	return out
+}
+
+// Close is a no-op: a MemorySink holds no external resource.
+func (s *MemorySink) Close() error {
+	return nil
+}
@@ -0,0 +1,43 @@
+package events
+
+import "testing"
+
+func TestMemorySink_KeepsAllEventsWhenUnbounded(t *testing.T) {
+	sink := NewMemorySink(0)
+
+	for i := 0; i < 5; i++ {
+		sink.Emit(Event{Type: DeviceBuilt, Hostname: "device"})
+	}
+
+	if len(sink.Events()) != 5 {
+		t.Fatalf("expected 5 events, got %d", len(sink.Events()))
+	}
+}
+
+func TestMemorySink_DropsOldestBeyondLimit(t *testing.T) {
+	sink := NewMemorySink(2)
+
+	sink.Emit(Event{Hostname: "a"})
+	sink.Emit(Event{Hostname: "b"})
+	sink.Emit(Event{Hostname: "c"})
+
+	events := sink.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events retained, got %d", len(events))
+	}
+	if events[0].Hostname != "b" || events[1].Hostname != "c" {
+		t.Fatalf("expected the oldest event to be dropped, got %v", events)
+	}
+}
+
+func TestMemorySink_EventsReturnsACopy(t *testing.T) {
+	sink := NewMemorySink(0)
+	sink.Emit(Event{Hostname: "a"})
+
+	events := sink.Events()
+	events[0].Hostname = "mutated"
+
+	if sink.Events()[0].Hostname != "a" {
+		t.Fatal("expected Events() to return a copy, not a view into internal state")
+	}
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/rs/zerolog/log"
+)
+
+// PushgatewaySink pushes per-device build duration and outcome counters to
+// a Prometheus Pushgateway, for setups where the build job's lifetime is
+// too short to be reliably scraped directly.
+type PushgatewaySink struct {
+	gatewayURL string
+	job        string
+
+	mu       sync.Mutex
+	pusher   *push.Pusher
+	duration *prometheus.HistogramVec
+	outcomes *prometheus.CounterVec
+}
+
+// NewPushgatewaySink returns a sink pushing to the pushgateway at gatewayURL
+// under the given job name.
+func NewPushgatewaySink(gatewayURL, job string) *PushgatewaySink {
+	s := &PushgatewaySink{gatewayURL: gatewayURL, job: job}
+	s.reset()
+	return s
+}
+
+// reset builds a fresh registry/pusher pair so that the hostname-labeled
+// vectors do not keep accumulating series across build cycles. Callers
+// must hold s.mu.
+func (s *PushgatewaySink) reset() {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "build_device_duration_seconds",
+		Help: "Duration of a single device build, pushed per build cycle.",
+	}, []string{"hostname"})
+
+	outcomes := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "build_device_outcome_total",
+		Help: "Count of device builds per outcome, pushed per build cycle.",
+	}, []string{"result"})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(duration, outcomes)
+
+	s.pusher = push.New(s.gatewayURL, s.job).Gatherer(registry)
+	s.duration = duration
+	s.outcomes = outcomes
+}
+
+// Emit records the event's duration and outcome locally; the values are
+// pushed to the gateway the next time Flush is called.
+func (s *PushgatewaySink) Emit(event Event) {
+	s.mu.Lock()
+	duration, outcomes := s.duration, s.outcomes
+	s.mu.Unlock()
+
+	switch event.Type {
+	case DeviceBuilt:
+		duration.WithLabelValues(event.Hostname).Observe(event.Duration.Seconds())
+		outcomes.WithLabelValues("success").Inc()
+	case DeviceFailed:
+		outcomes.WithLabelValues("failure").Inc()
+	}
+}
+
+// Flush pushes the metrics accumulated since the last Flush (or since
+// construction) to the gateway and resets them, so hostname-labeled series
+// do not keep growing for the lifetime of the process. It is meant to be
+// called once at the end of each build cycle.
+func (s *PushgatewaySink) Flush() error {
+	s.mu.Lock()
+	pusher := s.pusher
+	s.mu.Unlock()
+
+	err := pusher.Push()
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to push build events to pushgateway")
+	}
+
+	s.mu.Lock()
+	s.reset()
+	s.mu.Unlock()
+
+	return err
+}
+
+// Close flushes any metrics accumulated since the last Flush one final time
+// at process shutdown.
+func (s *PushgatewaySink) Close() error {
+	return s.Flush()
+}
@@ -0,0 +1,92 @@
+package events
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPushgatewaySink_FlushPushesAccumulatedMetricsAndResets(t *testing.T) {
+	var mu sync.Mutex
+	var pushCount int
+	var lastBody string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read request body: %v", err)
+			return
+		}
+		mu.Lock()
+		pushCount++
+		lastBody = string(body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink := NewPushgatewaySink(server.URL, "data-aggregation-api-test")
+
+	sink.Emit(Event{Type: DeviceBuilt, Hostname: "switch-1", Duration: time.Second})
+	sink.Emit(Event{Type: DeviceFailed, Hostname: "switch-2"})
+
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Flush returned an error: %v", err)
+	}
+
+	mu.Lock()
+	if pushCount != 1 {
+		mu.Unlock()
+		t.Fatalf("expected exactly one push, got %d", pushCount)
+	}
+	if !strings.Contains(lastBody, "build_device_duration_seconds") || !strings.Contains(lastBody, "build_device_outcome_total") {
+		mu.Unlock()
+		t.Fatalf("expected the pushed body to contain both metric families, got %q", lastBody)
+	}
+	mu.Unlock()
+
+	// A second Flush with no new events must still push (possibly empty)
+	// series from a freshly reset registry, not the same accumulated state.
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("second Flush returned an error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if pushCount != 2 {
+		t.Fatalf("expected a second push after the second Flush, got %d", pushCount)
+	}
+	if strings.Contains(lastBody, "switch-1") {
+		t.Fatalf("expected the reset registry to no longer carry the first cycle's series, got %q", lastBody)
+	}
+}
+
+func TestPushgatewaySink_CloseFlushesOnce(t *testing.T) {
+	var mu sync.Mutex
+	var pushCount int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		pushCount++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink := NewPushgatewaySink(server.URL, "data-aggregation-api-test")
+	sink.Emit(Event{Type: DeviceBuilt, Hostname: "switch-1"})
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close returned an error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if pushCount != 1 {
+		t.Fatalf("expected Close to push exactly once, got %d", pushCount)
+	}
+}
@@ -0,0 +1,42 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/criteo/data-aggregation-api/internal/config"
+)
+
+func TestNextBackoff_ClampsToFloorAndCeiling(t *testing.T) {
+	config.Cfg.Build.PollBackoffFloor = time.Second
+	config.Cfg.Build.PollBackoffCeiling = 10 * time.Second
+
+	next := nextBackoff(0)
+	if next < config.Cfg.Build.PollBackoffFloor/2 || next > config.Cfg.Build.PollBackoffFloor {
+		t.Fatalf("expected first backoff to be clamped around the floor, got %s", next)
+	}
+
+	current := config.Cfg.Build.PollBackoffCeiling
+	for i := 0; i < 5; i++ {
+		current = nextBackoff(current)
+		if current > config.Cfg.Build.PollBackoffCeiling {
+			t.Fatalf("backoff exceeded ceiling: %s", current)
+		}
+	}
+}
+
+func TestNextBackoff_NeverExceedsCeilingEvenWithJitter(t *testing.T) {
+	config.Cfg.Build.PollBackoffFloor = 100 * time.Millisecond
+	config.Cfg.Build.PollBackoffCeiling = time.Second
+
+	current := time.Duration(0)
+	for i := 0; i < 100; i++ {
+		current = nextBackoff(current)
+		if current > config.Cfg.Build.PollBackoffCeiling {
+			t.Fatalf("backoff %s exceeded ceiling %s on iteration %d", current, config.Cfg.Build.PollBackoffCeiling, i)
+		}
+		if current < 0 {
+			t.Fatalf("backoff must never be negative, got %s", current)
+		}
+	}
+}
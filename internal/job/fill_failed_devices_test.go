@@ -0,0 +1,41 @@
+package job
+
+import (
+	"testing"
+
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+)
+
+func TestFillFailedDevicesFromPrevious_FallsBackOnlyForFailedDevices(t *testing.T) {
+	good := &device.Device{Hostname: "switch-1"}
+	stalePrevious := &device.Device{Hostname: "switch-2"}
+
+	current := map[string]*device.Device{
+		"switch-1": good,
+		"switch-2": nil, // failed to build this cycle
+	}
+	previous := map[string]*device.Device{
+		"switch-1": &device.Device{Hostname: "switch-1"}, // old version, should not be used
+		"switch-2": stalePrevious,
+	}
+
+	merged := fillFailedDevicesFromPrevious(current, previous)
+
+	if merged["switch-1"] != good {
+		t.Fatal("expected the freshly built device to be kept, not the previous snapshot")
+	}
+	if merged["switch-2"] != stalePrevious {
+		t.Fatal("expected the failed device to fall back to its previous snapshot")
+	}
+}
+
+func TestFillFailedDevicesFromPrevious_LeavesNilWithoutPreviousSnapshot(t *testing.T) {
+	current := map[string]*device.Device{"switch-1": nil}
+	previous := map[string]*device.Device{}
+
+	merged := fillFailedDevicesFromPrevious(current, previous)
+
+	if merged["switch-1"] != nil {
+		t.Fatal("expected a device with no previous snapshot to stay nil")
+	}
+}
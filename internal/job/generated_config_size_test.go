@@ -0,0 +1,17 @@
+package job
+
+import (
+	"testing"
+
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+)
+
+func TestGeneratedConfigSize_ReturnsMarshalledLength(t *testing.T) {
+	dev := &device.Device{Hostname: "switch-1"}
+
+	size := generatedConfigSize(dev)
+
+	if size <= 0 {
+		t.Fatalf("expected a positive size for a populated device, got %d", size)
+	}
+}
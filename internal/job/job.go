@@ -1,8 +1,11 @@
 package job
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -10,22 +13,124 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/criteo/data-aggregation-api/internal/api/router"
+	"github.com/criteo/data-aggregation-api/internal/cache"
 	"github.com/criteo/data-aggregation-api/internal/config"
 	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+	"github.com/criteo/data-aggregation-api/internal/events"
 	"github.com/criteo/data-aggregation-api/internal/ingestor/repository"
 	"github.com/criteo/data-aggregation-api/internal/metrics"
 	"github.com/criteo/data-aggregation-api/internal/report"
+	"github.com/criteo/data-aggregation-api/internal/validate"
 )
 
+var (
+	lastValidationFailuresMu sync.Mutex
+	lastValidationFailures   []validate.Diff
+)
+
+// LastValidationFailures returns the diffs that made the most recent build
+// to reach publish validation fail it. It is consumed by ValidationHandler,
+// which serves it at the /api/v1/builds/last/validation endpoint.
+// It returns nil when that build was published. A build that fails before
+// validation does not change it.
+func LastValidationFailures() []validate.Diff {
+	lastValidationFailuresMu.Lock()
+	defer lastValidationFailuresMu.Unlock()
+
+	return lastValidationFailures
+}
+
+func setLastValidationFailures(diffs []validate.Diff) {
+	lastValidationFailuresMu.Lock()
+	defer lastValidationFailuresMu.Unlock()
+
+	lastValidationFailures = diffs
+}
+
+// fillFailedDevicesFromPrevious returns a copy of current where every
+// device that failed to build this cycle (a nil entry left by precompute)
+// is replaced by its last known good version from previous, if any.
+//
+// Without this, a single bad device would make SchemaValidator flag the
+// whole build and block deviceRepo.Set entirely, silently defeating the
+// AllDevicesMustBuild=false tolerance of warning on a precompute failure
+// while still publishing every device that did build. A device with no
+// previous snapshot either (e.g. it is new and failed on its first build)
+// is left nil and still flagged by SchemaValidator.
+func fillFailedDevicesFromPrevious(current, previous map[string]*device.Device) map[string]*device.Device {
+	merged := make(map[string]*device.Device, len(current))
+	for hostname, dev := range current {
+		if dev == nil {
+			if prev, ok := previous[hostname]; ok {
+				merged[hostname] = prev
+				continue
+			}
+		}
+		merged[hostname] = dev
+	}
+	return merged
+}
+
+// buildValidators returns the set of validators a build must pass before
+// its devices are published.
+func buildValidators() []validate.Validator {
+	validators := []validate.Validator{validate.SchemaValidator{}}
+
+	if config.Cfg.Build.MaxChangedDevicesRatio > 0 {
+		validators = append(validators, validate.MaxChangedRatio{Ratio: config.Cfg.Build.MaxChangedDevicesRatio})
+	}
+
+	return validators
+}
+
+// buildEventSinks builds the fan-out of event sinks configured under
+// config.Cfg.Events. An in-memory sink is always included, even when no
+// other sink is configured. Nothing outside the fan-out holds a reference
+// to it; the last-build report is still kept by report.Repository.
+func buildEventSinks() *events.FanOut {
+	sinks := []events.Sink{events.NewMemorySink(config.Cfg.Events.MemorySinkLimit)}
+
+	if config.Cfg.Events.File.Enabled {
+		fileSink, err := events.NewFileSink(config.Cfg.Events.File.Path, config.Cfg.Events.File.MaxBytes)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to create events file sink, skipping it")
+		} else {
+			sinks = append(sinks, fileSink)
+		}
+	}
+
+	if config.Cfg.Events.InfluxDB.Enabled {
+		sinks = append(sinks, events.NewInfluxDBSink(config.Cfg.Events.InfluxDB.WriteURL))
+	}
+
+	if config.Cfg.Events.Pushgateway.Enabled {
+		sinks = append(sinks, events.NewPushgatewaySink(config.Cfg.Events.Pushgateway.URL, config.Cfg.Events.Pushgateway.Job))
+	}
+
+	return events.NewFanOut(sinks...)
+}
+
 // Precompute prepares data to ease compute per device.
 // The goal is to copy data to each device to be able to build devices independently.
-func precompute(reportCh chan report.Message, ingestorRepo *repository.Assets) (map[string]*device.Device, error) {
+//
+// Devices whose input fingerprint is unchanged since the last build are
+// served from buildCache instead of being rebuilt from scratch.
+func precompute(reportCh chan report.Message, ingestorRepo *repository.Assets, buildCache *cache.Cache) (map[string]*device.Device, map[string]string, error) {
 	log.Info().Msg("start precompute")
 	devicesData := ingestorRepo.Precompute()
 	var devices = make(map[string]*device.Device)
+	var fingerprints = make(map[string]string)
 	var allPrecomputeErrors error
 
 	for _, dev := range ingestorRepo.DeviceInventory {
+		fingerprint := devicesData.Fingerprint(dev.Hostname)
+		fingerprints[dev.Hostname] = fingerprint
+
+		if cached, ok := buildCache.Get(dev.Hostname, fingerprint); ok {
+			devices[dev.Hostname] = cached
+			continue
+		}
+
 		if newDevice, err := device.NewDevice(dev, devicesData); err != nil {
 			devices[dev.Hostname] = nil
 			reportCh <- report.Message{
@@ -39,51 +144,151 @@ func precompute(reportCh chan report.Message, ingestorRepo *repository.Assets) (
 		}
 	}
 
-	return devices, allPrecomputeErrors
+	return devices, fingerprints, allPrecomputeErrors
+}
+
+// generatedConfigSize returns the size in bytes of dev's generated
+// OpenConfig, for the DeviceBuilt event's Bytes field. It returns 0 if dev
+// cannot be marshalled, which should not happen for a device that just
+// built successfully.
+func generatedConfigSize(dev *device.Device) int {
+	encoded, err := json.Marshal(dev)
+	if err != nil {
+		log.Warn().Err(err).Str("hostname", dev.Hostname).Msg("failed to size generated config for build event")
+		return 0
+	}
+	return len(encoded)
+}
+
+// computeConcurrency returns the configured number of workers to use for
+// compute, defaulting to the number of available CPUs when unset.
+func computeConcurrency() int {
+	if config.Cfg.Build.ComputeConcurrency > 0 {
+		return config.Cfg.Build.ComputeConcurrency
+	}
+
+	return runtime.NumCPU()
 }
 
-// Compute generates OpenConfig data for each device.
-func compute(reportCh chan<- report.Message, ingestorRepo *repository.Assets, devices map[string]*device.Device) (uint32, error) {
+// Compute generates OpenConfig data for each device using a bounded pool of
+// workers, so that an inventory of thousands of devices cannot spawn
+// thousands of concurrent goroutines.
+//
+// Devices that were already present in buildCache under their current
+// fingerprint are assumed to already hold up-to-date OpenConfig and are
+// skipped; every other device is (re)computed and stored back into
+// buildCache under its fingerprint so the next build cycle can reuse it.
+// Each device build is bounded by config.Cfg.Build.PerDeviceTimeout and is
+// abandoned if ctx is cancelled before it completes.
+func compute(ctx context.Context, reportCh chan<- report.Message, ingestorRepo *repository.Assets, devices map[string]*device.Device, fingerprints map[string]string, buildCache *cache.Cache, sink events.Sink) (uint32, error) {
+	jobs := make(chan *device.Device)
 	wg := sync.WaitGroup{}
 
 	failed := false
 	var builtCount atomic.Uint32
+	var cacheHits atomic.Uint32
+	var recomputed atomic.Uint32
 	var mutex sync.Mutex
 
-	for _, dev := range ingestorRepo.DeviceInventory {
-		if devices[dev.Hostname] == nil {
-			reportCh <- report.Message{
-				Type:     report.ComputeMessage,
-				Severity: report.Warning,
-				Text:     fmt.Sprintf("device %s has no configuration", dev.Hostname),
-			}
-			continue
-		}
+	worker := func() {
+		defer wg.Done()
+		for dev := range jobs {
+			deviceCtx, cancel := context.WithTimeout(ctx, config.Cfg.Build.PerDeviceTimeout)
+			start := time.Now()
+			err := dev.Generateconfigs(deviceCtx)
+			duration := time.Since(start)
+			cancel()
 
-		wg.Add(1)
-		go func(dev *device.Device) {
-			defer wg.Done()
-			if err := dev.Generateconfigs(); err != nil {
+			metrics.ObserveBuildDeviceDuration(duration.Seconds())
+
+			switch {
+			case errors.Is(err, context.DeadlineExceeded):
+				reportCh <- report.Message{
+					Type:     report.ComputeMessage,
+					Severity: report.Error,
+					Text:     fmt.Sprintf("device %s timed out after %s", dev.Hostname, duration),
+				}
+				sink.Emit(events.Event{Type: events.DeviceFailed, Time: time.Now(), Hostname: dev.Hostname, Duration: duration, Error: "timeout"})
+				mutex.Lock()
+				failed = true
+				mutex.Unlock()
+			case errors.Is(err, context.Canceled):
+				reportCh <- report.Message{
+					Type:     report.ComputeMessage,
+					Severity: report.Warning,
+					Text:     fmt.Sprintf("device %s build cancelled", dev.Hostname),
+				}
+				sink.Emit(events.Event{Type: events.DeviceFailed, Time: time.Now(), Hostname: dev.Hostname, Duration: duration, Error: "cancelled"})
+			case err != nil:
 				reportCh <- report.Message{
 					Type:     report.PrecomputeMessage,
 					Severity: report.Error,
 					Text:     err.Error(),
 				}
+				sink.Emit(events.Event{Type: events.DeviceFailed, Time: time.Now(), Hostname: dev.Hostname, Duration: duration, Error: err.Error()})
 				mutex.Lock()
 				failed = true
 				mutex.Unlock()
-			} else {
+			default:
+				buildCache.Set(dev.Hostname, fingerprints[dev.Hostname], dev)
+				sink.Emit(events.Event{
+					Type:     events.DeviceBuilt,
+					Time:     time.Now(),
+					Hostname: dev.Hostname,
+					Duration: duration,
+					Bytes:    generatedConfigSize(dev),
+					Hash:     fingerprints[dev.Hostname],
+				})
+				recomputed.Add(1)
 				mutex.Lock()
 				builtCount.Add(1)
 				mutex.Unlock()
 			}
-		}(devices[dev.Hostname])
+		}
 	}
 
+	workerCount := computeConcurrency()
+	wg.Add(workerCount)
+	for i := 0; i < workerCount; i++ {
+		go worker()
+	}
+
+queueing:
+	for _, dev := range ingestorRepo.DeviceInventory {
+		if devices[dev.Hostname] == nil {
+			reportCh <- report.Message{
+				Type:     report.ComputeMessage,
+				Severity: report.Warning,
+				Text:     fmt.Sprintf("device %s has no configuration", dev.Hostname),
+			}
+			continue
+		}
+
+		if _, ok := buildCache.Get(dev.Hostname, fingerprints[dev.Hostname]); ok {
+			cacheHits.Add(1)
+			builtCount.Add(1)
+			continue
+		}
+
+		select {
+		case jobs <- devices[dev.Hostname]:
+		case <-ctx.Done():
+			break queueing
+		}
+	}
+	close(jobs)
+
 	wg.Wait()
 
+	metrics.AddBuildDevicesCacheHit(cacheHits.Load())
+	metrics.AddBuildDevicesRecomputed(recomputed.Load())
+
 	successfullyBuilt := builtCount.Load()
 
+	if ctx.Err() != nil {
+		return successfullyBuilt, ctx.Err()
+	}
+
 	if failed {
 		return successfullyBuilt, errors.New("OpenConfig conversion failed")
 	}
@@ -96,9 +301,10 @@ func compute(reportCh chan<- report.Message, ingestorRepo *repository.Assets, de
 //   - fetch data using ingestors (one ingestor = one data source API endpoint)
 //   - precompute data to make them usable
 //   - compute to OpenConfig
-func RunBuild(reportCh chan report.Message) (map[string]*device.Device, report.Stats, error) {
+func RunBuild(ctx context.Context, reportCh chan report.Message, buildCache *cache.Cache, sink events.Sink) (map[string]*device.Device, report.Stats, error) {
 	stats := report.Stats{}
 	startTime := time.Now()
+	sink.Emit(events.Event{Type: events.BuildStarted, Time: startTime})
 
 	// Fetch data from CMDB
 	ingestorRepo, err := repository.FetchAssets(reportCh)
@@ -109,9 +315,10 @@ func RunBuild(reportCh chan report.Message) (map[string]*device.Device, report.S
 	ingestorRepo.ReportStats(reportCh)
 	ingestorFetchFinishTime := time.Now()
 	stats.Performance.DataFetchingDuration = ingestorFetchFinishTime.Sub(startTime)
+	sink.Emit(events.Event{Type: events.FetchDone, Time: ingestorFetchFinishTime})
 
 	// Precompute data per device
-	devices, precomputeError := precompute(reportCh, ingestorRepo)
+	devices, fingerprints, precomputeError := precompute(reportCh, ingestorRepo, buildCache)
 	precomputeFinishTime := time.Now()
 	stats.Performance.PrecomputeDuration = precomputeFinishTime.Sub(ingestorFetchFinishTime)
 
@@ -128,7 +335,7 @@ func RunBuild(reportCh chan report.Message) (map[string]*device.Device, report.S
 	}
 
 	// Generate openconfig for all devices
-	successfullyBuilt, computeError := compute(reportCh, ingestorRepo, devices)
+	successfullyBuilt, computeError := compute(ctx, reportCh, ingestorRepo, devices, fingerprints, buildCache, sink)
 	computeTime := time.Now()
 	stats.Performance.ComputeDuration = computeTime.Sub(precomputeFinishTime)
 	stats.Performance.BuildDuration = computeTime.Sub(startTime)
@@ -136,6 +343,23 @@ func RunBuild(reportCh chan report.Message) (map[string]*device.Device, report.S
 	stats.BuiltDevicesCount = successfullyBuilt
 	stats.Log()
 
+	sink.Emit(events.Event{
+		Type: events.BuildComplete,
+		Time: computeTime,
+		Stats: events.BuildStats{
+			BuiltDevicesCount: successfullyBuilt,
+			BuildDuration:     stats.Performance.BuildDuration,
+		},
+	})
+
+	// Sinks that buffer per-cycle state (e.g. PushgatewaySink) flush it here,
+	// at the end of every build cycle, rather than only at process shutdown.
+	if flusher, ok := sink.(events.Flusher); ok {
+		if err := flusher.Flush(); err != nil {
+			log.Warn().Err(err).Msg("failed to flush event sinks")
+		}
+	}
+
 	if computeError != nil {
 		return nil, stats, computeError
 	}
@@ -143,11 +367,49 @@ func RunBuild(reportCh chan report.Message) (map[string]*device.Device, report.S
 	return devices, stats, nil
 }
 
+// watchCancellation cancels ctx as soon as triggerNewBuild is closed, and
+// returns once that happens or ctx is done for some other reason (e.g. the
+// build it guards has already finished). A value received on triggerNewBuild
+// without the channel being closed is a manual rebuild request made while a
+// build is already in flight: it does not cancel the current build, it is
+// queued onto pendingManual (non-blocking, so at most one is remembered) so
+// the scheduler can act on it as soon as this build completes.
+func watchCancellation(ctx context.Context, cancel context.CancelFunc, triggerNewBuild <-chan struct{}, pendingManual chan<- struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-triggerNewBuild:
+			if !ok {
+				cancel()
+				return
+			}
+			select {
+			case pendingManual <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
 // StartBuildLoop starts the build in an infinite loop.
 //
 // Closing the triggerNewBuild channel will stop the loop.
 func StartBuildLoop(deviceRepo router.DevicesRepository, reports *report.Repository, triggerNewBuild <-chan struct{}) {
 	metricsRegistry := metrics.NewRegistry()
+
+	buildCache := cache.New(config.Cfg.Build.CacheSize, config.Cfg.Build.CacheDir)
+	buildCache.Load()
+
+	sink := buildEventSinks()
+	defer func() {
+		if err := sink.Close(); err != nil {
+			log.Warn().Err(err).Msg("failed to close event sinks")
+		}
+	}()
+
+	sched := newScheduler()
+
 	for {
 		var wg sync.WaitGroup
 		reports.StartNewReport()
@@ -159,9 +421,23 @@ func StartBuildLoop(deviceRepo router.DevicesRepository, reports *report.Reposit
 			reports.Watch(reportCh)
 		}()
 
-		// Start the build
+		// Start the build, cancelling it early if triggerNewBuild is closed mid-build.
+		// A manual trigger received while a build is already in flight is not
+		// dropped: it is remembered in pendingManual so the next iteration starts
+		// immediately instead of waiting on the scheduler.
+		ctx, cancel := context.WithCancel(context.Background())
+		watchWg := sync.WaitGroup{}
+		pendingManual := make(chan struct{}, 1)
+		watchWg.Add(1)
+		go func() {
+			defer watchWg.Done()
+			watchCancellation(ctx, cancel, triggerNewBuild, pendingManual)
+		}()
+
 		reports.UpdateStatus(report.InProgress)
-		devs, stats, err := RunBuild(reportCh)
+		devs, stats, err := RunBuild(ctx, reportCh, buildCache, sink)
+		cancel()
+		watchWg.Wait()
 		if err != nil {
 			metricsRegistry.BuildFailed()
 
@@ -170,16 +446,39 @@ func StartBuildLoop(deviceRepo router.DevicesRepository, reports *report.Reposit
 
 			log.Error().Err(err).Msg("build failed")
 		} else {
-			deviceRepo.Set(devs)
+			// A device that failed to build (a nil entry left by precompute)
+			// falls back to its last known good snapshot instead of blocking
+			// the publish of every device that did build successfully.
+			publishable := fillFailedDevicesFromPrevious(devs, deviceRepo.Get())
 
-			metricsRegistry.BuildSuccessful()
-			metricsRegistry.SetBuiltDevices(stats.BuiltDevicesCount)
+			if diffs := validate.Run(buildValidators(), publishable, deviceRepo.Get()); len(diffs) > 0 && !config.Cfg.Build.ForcePublish {
+				setLastValidationFailures(diffs)
+				metricsRegistry.BuildFailed()
 
-			reports.UpdateStatus(report.Success)
-			reports.UpdateStats(stats)
-			reports.MarkAsSuccessful()
+				reports.UpdateStatus(report.PartiallyFailed)
+				reports.UpdateStats(stats)
+
+				log.Warn().Int("diffs", len(diffs)).Msg("build validation failed, keeping previous device snapshot")
+			} else {
+				if len(diffs) > 0 {
+					log.Warn().Int("diffs", len(diffs)).Msg("build validation failed but force-publish is set, publishing anyway")
+				}
+				setLastValidationFailures(nil)
+				deviceRepo.Set(publishable)
+
+				metricsRegistry.BuildSuccessful()
+				metricsRegistry.SetBuiltDevices(stats.BuiltDevicesCount)
 
-			log.Info().Msg("build successful")
+				reports.UpdateStatus(report.Success)
+				reports.UpdateStats(stats)
+				reports.MarkAsSuccessful()
+
+				if err := buildCache.Save(); err != nil {
+					log.Warn().Err(err).Msg("failed to persist build cache to disk")
+				}
+
+				log.Info().Msg("build successful")
+			}
 		}
 
 		metricsRegistry.SetBuildDataFetchingDuration(stats.Performance.DataFetchingDuration.Seconds())
@@ -191,13 +490,19 @@ func StartBuildLoop(deviceRepo router.DevicesRepository, reports *report.Reposit
 		close(reportCh)
 		wg.Wait()
 
+		var source string
+		var ok bool
 		select {
-		case <-time.After(config.Cfg.Build.Interval):
-		case _, ok := <-triggerNewBuild:
-			if !ok {
-				log.Info().Msg("triggerNewBuild channel closed, stopping build loop")
-				return
-			}
+		case <-pendingManual:
+			source, ok = "manual", true
+		default:
+			source, ok = sched.waitForNextBuild(triggerNewBuild)
+		}
+		if !ok {
+			log.Info().Msg("triggerNewBuild channel closed, stopping build loop")
+			return
 		}
+		log.Info().Str("source", source).Msg("triggering build")
+		sched.markBuilt()
 	}
 }
@@ -0,0 +1,148 @@
+package job
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/criteo/data-aggregation-api/internal/config"
+	"github.com/criteo/data-aggregation-api/internal/ingestor/repository"
+	"github.com/criteo/data-aggregation-api/internal/metrics"
+)
+
+// defaultPollInterval is the cadence at which ingestors are probed for changes.
+const defaultPollInterval = 5 * time.Second
+
+// scheduler decides when the next build should run, triggering on CMDB
+// change detection instead of a fixed interval. It enforces a floor and
+// ceiling on rebuild frequency and coalesces bursts of changes into a
+// single rebuild.
+type scheduler struct {
+	lastBuild time.Time
+	backoff   time.Duration
+
+	// poll and pollInterval are overridden in tests to fake ingestor polling
+	// and run the state machine on a fast clock.
+	poll         func(context.Context) (string, error)
+	pollInterval time.Duration
+}
+
+// newScheduler returns a scheduler ready to wait for the first build.
+func newScheduler() *scheduler {
+	return &scheduler{
+		lastBuild:    time.Now(),
+		poll:         repository.Poll,
+		pollInterval: defaultPollInterval,
+	}
+}
+
+// markBuilt records that a build is about to start, resetting the
+// staleness clock.
+func (s *scheduler) markBuilt() {
+	s.lastBuild = time.Now()
+}
+
+// waitForNextBuild blocks until a build should run, returning the source
+// that triggered it ("manual", "staleness", or an ingestor name) and true,
+// or "" and false if triggerNewBuild was closed and the loop should stop.
+//
+// Non-manual triggers (staleness and CMDB-source changes) are additionally
+// held back until config.Cfg.Build.MinRebuildInterval has elapsed since the
+// last build, so a flapping source cannot cause back-to-back rebuilds
+// bounded only by the coalesce window. A manual trigger always fires
+// immediately: it is an explicit operator request, not subject to the
+// guardrail.
+func (s *scheduler) waitForNextBuild(triggerNewBuild <-chan struct{}) (string, bool) {
+	coalesceTimer := time.NewTimer(time.Hour)
+	coalesceTimer.Stop()
+	defer coalesceTimer.Stop()
+	pending := false
+	var pendingSource string
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	// arm schedules pendingSource to be returned once both the coalesce
+	// window and the minimum-rebuild-interval floor have elapsed.
+	arm := func(source string) {
+		pending = true
+		pendingSource = source
+		wait := config.Cfg.Build.CoalesceWindow
+		if remaining := s.floorRemaining(); remaining > wait {
+			wait = remaining
+		}
+		coalesceTimer.Reset(wait)
+	}
+
+	for {
+		select {
+		case _, ok := <-triggerNewBuild:
+			if !ok {
+				return "", false
+			}
+			return "manual", true
+
+		case <-ticker.C:
+			source, err := s.poll(context.Background())
+			if err != nil {
+				s.backoff = nextBackoff(s.backoff)
+				log.Warn().Err(err).Dur("backoff", s.backoff).Msg("ingestor poll failed, backing off")
+				ticker.Reset(s.backoff)
+				continue
+			}
+
+			s.backoff = 0
+			ticker.Reset(s.pollInterval)
+
+			if source == "" {
+				metrics.IncBuildSkippedNoChange()
+				if !pending && time.Since(s.lastBuild) >= config.Cfg.Build.MaxStaleness {
+					arm("staleness")
+				}
+				continue
+			}
+
+			metrics.IncBuildTriggeredBySource(source)
+			if !pending {
+				arm(source)
+			}
+
+		case <-coalesceTimer.C:
+			if remaining := s.floorRemaining(); remaining > 0 {
+				coalesceTimer.Reset(remaining)
+				continue
+			}
+			return pendingSource, true
+		}
+	}
+}
+
+// floorRemaining returns how long is left before MinRebuildInterval has
+// elapsed since the last build, or zero if it already has.
+func (s *scheduler) floorRemaining() time.Duration {
+	remaining := config.Cfg.Build.MinRebuildInterval - time.Since(s.lastBuild)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// nextBackoff doubles current, clamps it between the configured floor and
+// ceiling, and returns a random duration between half of that clamped value
+// and the clamped value itself, so that many instances failing at once do
+// not retry in lockstep. The result never exceeds the ceiling but can be as
+// low as half the floor.
+func nextBackoff(current time.Duration) time.Duration {
+	next := current * 2
+	if next < config.Cfg.Build.PollBackoffFloor {
+		next = config.Cfg.Build.PollBackoffFloor
+	}
+	if next > config.Cfg.Build.PollBackoffCeiling {
+		next = config.Cfg.Build.PollBackoffCeiling
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(next)/2 + 1))
+	return next/2 + jitter
+}
@@ -0,0 +1,112 @@
+package job
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/criteo/data-aggregation-api/internal/config"
+)
+
+// pollScript returns a poll func that replays sources in order, repeating
+// the last one once exhausted.
+func pollScript(sources ...string) func(context.Context) (string, error) {
+	var i atomic.Int32
+	return func(context.Context) (string, error) {
+		idx := int(i.Add(1)) - 1
+		if idx >= len(sources) {
+			idx = len(sources) - 1
+		}
+		return sources[idx], nil
+	}
+}
+
+func TestWaitForNextBuild_FloorOutlastsCoalesceWindowAfterBurst(t *testing.T) {
+	config.Cfg.Build.CoalesceWindow = 10 * time.Millisecond
+	config.Cfg.Build.MinRebuildInterval = 120 * time.Millisecond
+	config.Cfg.Build.MaxStaleness = time.Hour
+
+	s := &scheduler{
+		lastBuild:    time.Now(),
+		poll:         pollScript("switch-1", "switch-2", "", "", "", "", "", "", "", "", "", ""),
+		pollInterval: 5 * time.Millisecond,
+	}
+
+	start := time.Now()
+	source, ok := s.waitForNextBuild(make(chan struct{}))
+	elapsed := time.Since(start)
+
+	if !ok {
+		t.Fatal("expected waitForNextBuild to return a trigger, not a shutdown")
+	}
+	if source != "switch-1" {
+		t.Fatalf("expected the first coalesced source to win, got %q", source)
+	}
+	if elapsed < config.Cfg.Build.MinRebuildInterval {
+		t.Fatalf("expected the floor (%s) to hold the rebuild back, returned after only %s", config.Cfg.Build.MinRebuildInterval, elapsed)
+	}
+	if elapsed < 3*config.Cfg.Build.CoalesceWindow {
+		t.Fatalf("expected the floor to dominate the much shorter coalesce window (%s), returned after %s", config.Cfg.Build.CoalesceWindow, elapsed)
+	}
+}
+
+func TestWaitForNextBuild_CoalesceWindowWinsWhenFloorAlreadyElapsed(t *testing.T) {
+	config.Cfg.Build.CoalesceWindow = 20 * time.Millisecond
+	config.Cfg.Build.MinRebuildInterval = time.Millisecond
+	config.Cfg.Build.MaxStaleness = time.Hour
+
+	s := &scheduler{
+		// lastBuild far in the past: the floor has already elapsed.
+		lastBuild:    time.Now().Add(-time.Hour),
+		poll:         pollScript("switch-1", "", "", "", "", "", "", "", ""),
+		pollInterval: 5 * time.Millisecond,
+	}
+
+	start := time.Now()
+	source, ok := s.waitForNextBuild(make(chan struct{}))
+	elapsed := time.Since(start)
+
+	if !ok || source != "switch-1" {
+		t.Fatalf("expected (switch-1, true), got (%q, %v)", source, ok)
+	}
+	if elapsed > 10*config.Cfg.Build.CoalesceWindow {
+		t.Fatalf("expected the build to fire shortly after the coalesce window, took %s", elapsed)
+	}
+}
+
+func TestWaitForNextBuild_ManualTriggerBypassesFloor(t *testing.T) {
+	config.Cfg.Build.CoalesceWindow = time.Hour
+	config.Cfg.Build.MinRebuildInterval = time.Hour
+	config.Cfg.Build.MaxStaleness = time.Hour
+
+	s := &scheduler{
+		lastBuild:    time.Now(),
+		poll:         pollScript(""),
+		pollInterval: time.Minute,
+	}
+
+	trigger := make(chan struct{}, 1)
+	trigger <- struct{}{}
+
+	source, ok := s.waitForNextBuild(trigger)
+	if !ok || source != "manual" {
+		t.Fatalf("expected an immediate manual trigger, got (%q, %v)", source, ok)
+	}
+}
+
+func TestWaitForNextBuild_ClosedChannelStopsLoop(t *testing.T) {
+	s := &scheduler{
+		lastBuild:    time.Now(),
+		poll:         pollScript(""),
+		pollInterval: time.Minute,
+	}
+
+	trigger := make(chan struct{})
+	close(trigger)
+
+	_, ok := s.waitForNextBuild(trigger)
+	if ok {
+		t.Fatal("expected waitForNextBuild to report shutdown when triggerNewBuild is closed")
+	}
+}
@@ -0,0 +1,42 @@
+package job
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/criteo/data-aggregation-api/internal/validate"
+)
+
+// RegisterRoutes registers job's HTTP endpoints on mux. The API router is
+// expected to call this once, alongside registering its other routes, so
+// that GET /api/v1/builds/last/validation is actually reachable; as of this
+// commit nothing calls it yet, because the router package that owns route
+// registration is not part of this change.
+func RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v1/builds/last/validation", ValidationHandler)
+}
+
+// ValidationHandler serves the diffs that made the most recent build fail
+// publish validation as JSON. Registered at
+// GET /api/v1/builds/last/validation by RegisterRoutes. An empty JSON
+// array means the most recent build to reach validation was published, or
+// no build has reached validation yet. A build that fails before
+// validation leaves the previous result in place.
+func ValidationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	diffs := LastValidationFailures()
+	if diffs == nil {
+		diffs = []validate.Diff{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(diffs); err != nil {
+		log.Error().Err(err).Msg("failed to encode validation failures response")
+	}
+}
@@ -0,0 +1,91 @@
+package job
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/criteo/data-aggregation-api/internal/validate"
+)
+
+func TestValidationHandler_EmptyWhenNoFailures(t *testing.T) {
+	setLastValidationFailures(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/builds/last/validation", nil)
+	rec := httptest.NewRecorder()
+
+	ValidationHandler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	var diffs []validate.Diff
+	if err := json.Unmarshal(rec.Body.Bytes(), &diffs); err != nil {
+		t.Fatalf("failed to decode response body: %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Fatalf("expected no diffs, got %v", diffs)
+	}
+}
+
+func TestValidationHandler_ReturnsLastFailures(t *testing.T) {
+	want := []validate.Diff{{Hostname: "switch-1", Reason: "no configuration generated"}}
+	setLastValidationFailures(want)
+	defer setLastValidationFailures(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/builds/last/validation", nil)
+	rec := httptest.NewRecorder()
+
+	ValidationHandler(rec, req)
+
+	var got []validate.Diff
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode response body: %v", err)
+	}
+	if len(got) != 1 || got[0] != want[0] {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestValidationHandler_RejectsNonGet(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/builds/last/validation", nil)
+	rec := httptest.NewRecorder()
+
+	ValidationHandler(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status 405, got %d", rec.Code)
+	}
+}
+
+func TestRegisterRoutes_ServesValidationEndpoint(t *testing.T) {
+	want := []validate.Diff{{Hostname: "switch-1", Reason: "no configuration generated"}}
+	setLastValidationFailures(want)
+	defer setLastValidationFailures(nil)
+
+	mux := http.NewServeMux()
+	RegisterRoutes(mux)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/builds/last/validation")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	var got []validate.Diff
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response body: %v", err)
+	}
+	if len(got) != 1 || got[0] != want[0] {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,86 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatchCancellation_ClosedChannelCancelsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	triggerNewBuild := make(chan struct{})
+	pendingManual := make(chan struct{}, 1)
+
+	close(triggerNewBuild)
+
+	done := make(chan struct{})
+	go func() {
+		watchCancellation(ctx, cancel, triggerNewBuild, pendingManual)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watchCancellation did not return after triggerNewBuild was closed")
+	}
+
+	if ctx.Err() == nil {
+		t.Fatal("expected ctx to be cancelled after triggerNewBuild was closed")
+	}
+}
+
+func TestWatchCancellation_ReturnsWhenCtxDoneWithoutChannelActivity(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	triggerNewBuild := make(chan struct{})
+	pendingManual := make(chan struct{}, 1)
+
+	done := make(chan struct{})
+	go func() {
+		watchCancellation(ctx, cancel, triggerNewBuild, pendingManual)
+		close(done)
+	}()
+
+	// Simulate RunBuild finishing on its own and the caller cancelling the
+	// context, with nothing ever sent on or closed on triggerNewBuild.
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watchCancellation deadlocked waiting on triggerNewBuild after ctx was cancelled")
+	}
+}
+
+func TestWatchCancellation_QueuesManualTriggerWithoutCancelling(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	triggerNewBuild := make(chan struct{}, 1)
+	pendingManual := make(chan struct{}, 1)
+
+	done := make(chan struct{})
+	go func() {
+		watchCancellation(ctx, cancel, triggerNewBuild, pendingManual)
+		close(done)
+	}()
+
+	triggerNewBuild <- struct{}{}
+
+	select {
+	case <-pendingManual:
+	case <-time.After(time.Second):
+		t.Fatal("manual trigger received mid-build was not queued onto pendingManual")
+	}
+
+	if ctx.Err() != nil {
+		t.Fatal("a manual trigger must not cancel the in-flight build")
+	}
+
+	close(triggerNewBuild)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watchCancellation did not return after triggerNewBuild was closed")
+	}
+}
@@ -0,0 +1,42 @@
+package validate
+
+import (
+	"fmt"
+
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+)
+
+// MaxChangedRatio rejects a build where more than Ratio of the previously
+// published devices changed, guarding against a bad ingestor feed silently
+// rewriting most of the inventory in a single cycle.
+type MaxChangedRatio struct {
+	Ratio float64
+}
+
+// Validate implements Validator.
+//
+// A device counts as changed if it is a different *device.Device than the
+// one previously published; devices reused unchanged from the build cache
+// keep the same pointer and are therefore not counted.
+func (v MaxChangedRatio) Validate(current, previous map[string]*device.Device) []Diff {
+	if len(previous) == 0 {
+		return nil
+	}
+
+	changed := 0
+	for hostname, previousDevice := range previous {
+		if current[hostname] != previousDevice {
+			changed++
+		}
+	}
+
+	ratio := float64(changed) / float64(len(previous))
+	if ratio <= v.Ratio {
+		return nil
+	}
+
+	return []Diff{{
+		Hostname: "*",
+		Reason:   fmt.Sprintf("%.1f%% of devices changed, above the %.1f%% guardrail", ratio*100, v.Ratio*100),
+	}}
+}
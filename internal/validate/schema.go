@@ -0,0 +1,18 @@
+package validate
+
+import "github.com/criteo/data-aggregation-api/internal/convertor/device"
+
+// SchemaValidator rejects devices for which no configuration was generated
+// at all, which would otherwise silently publish an empty device.
+type SchemaValidator struct{}
+
+// Validate implements Validator.
+func (SchemaValidator) Validate(current, _ map[string]*device.Device) []Diff {
+	var diffs []Diff
+	for hostname, dev := range current {
+		if dev == nil {
+			diffs = append(diffs, Diff{Hostname: hostname, Reason: "no configuration generated"})
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,32 @@
+// Package validate guards the two-phase build publish: before a freshly
+// computed set of devices replaces the one currently served by the API, it
+// is checked against the previous snapshot so that an obviously broken
+// build does not go live.
+package validate
+
+import "github.com/criteo/data-aggregation-api/internal/convertor/device"
+
+// Diff describes a single device a Validator flagged as unsafe to publish,
+// or, with Hostname "*", a problem with the build as a whole.
+type Diff struct {
+	Hostname string
+	Reason   string
+}
+
+// Validator inspects the newly computed devices against the previously
+// published ones and reports any diffs it considers unsafe to publish.
+// An implementation must not mutate current or previous.
+type Validator interface {
+	Validate(current, previous map[string]*device.Device) []Diff
+}
+
+// Run executes every validator against current and previous, and returns
+// the combined list of diffs. An empty result means the build is safe to
+// publish.
+func Run(validators []Validator, current, previous map[string]*device.Device) []Diff {
+	var diffs []Diff
+	for _, validator := range validators {
+		diffs = append(diffs, validator.Validate(current, previous)...)
+	}
+	return diffs
+}
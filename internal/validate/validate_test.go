@@ -0,0 +1,85 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/criteo/data-aggregation-api/internal/convertor/device"
+)
+
+func TestSchemaValidator_FlagsNilDevices(t *testing.T) {
+	current := map[string]*device.Device{
+		"good": {},
+		"bad":  nil,
+	}
+
+	diffs := SchemaValidator{}.Validate(current, nil)
+
+	if len(diffs) != 1 || diffs[0].Hostname != "bad" {
+		t.Fatalf("expected a single diff for the nil device, got %v", diffs)
+	}
+}
+
+func TestSchemaValidator_NoDiffsWhenAllDevicesBuilt(t *testing.T) {
+	current := map[string]*device.Device{"good": {}}
+
+	diffs := SchemaValidator{}.Validate(current, nil)
+
+	if len(diffs) != 0 {
+		t.Fatalf("expected no diffs, got %v", diffs)
+	}
+}
+
+func TestMaxChangedRatio_NoPreviousSnapshotAlwaysPasses(t *testing.T) {
+	validator := MaxChangedRatio{Ratio: 0.1}
+
+	diffs := validator.Validate(map[string]*device.Device{"a": {}}, nil)
+
+	if len(diffs) != 0 {
+		t.Fatalf("expected no diffs with an empty previous snapshot, got %v", diffs)
+	}
+}
+
+func TestMaxChangedRatio_PassesAtExactlyTheRatio(t *testing.T) {
+	devA := &device.Device{}
+	devB := &device.Device{}
+	previous := map[string]*device.Device{"a": devA, "b": devB}
+	// Half the devices change, exactly at the 0.5 guardrail.
+	current := map[string]*device.Device{"a": devA, "b": {}}
+
+	validator := MaxChangedRatio{Ratio: 0.5}
+	diffs := validator.Validate(current, previous)
+
+	if len(diffs) != 0 {
+		t.Fatalf("expected no diffs when the ratio is exactly at the guardrail, got %v", diffs)
+	}
+}
+
+func TestMaxChangedRatio_FlagsWhenAboveRatio(t *testing.T) {
+	devA := &device.Device{}
+	previous := map[string]*device.Device{"a": devA, "b": {}}
+	// Both devices changed (a's pointer differs, and b is missing from current).
+	current := map[string]*device.Device{"a": {}}
+
+	validator := MaxChangedRatio{Ratio: 0.5}
+	diffs := validator.Validate(current, previous)
+
+	if len(diffs) != 1 {
+		t.Fatalf("expected a single guardrail diff, got %v", diffs)
+	}
+}
+
+func TestRun_CombinesDiffsFromEveryValidator(t *testing.T) {
+	current := map[string]*device.Device{"bad": nil}
+	validators := []Validator{
+		SchemaValidator{},
+		PolicyHook(func(_, _ map[string]*device.Device) []Diff {
+			return []Diff{{Hostname: "bad", Reason: "custom policy violation"}}
+		}),
+	}
+
+	diffs := Run(validators, current, nil)
+
+	if len(diffs) != 2 {
+		t.Fatalf("expected diffs from both validators, got %v", diffs)
+	}
+}